@@ -0,0 +1,315 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fleetdm/orbit/pkg/constant"
+	"github.com/fleetdm/orbit/pkg/platform"
+	"github.com/pkg/errors"
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// bundleFormat identifies the archive format of a bundled target.
+type bundleFormat int
+
+const (
+	bundleFormatUnknown bundleFormat = iota
+	bundleFormatTarGz
+	bundleFormatZip
+)
+
+// BundleOptions customizes how GetBundle extracts an archived target.
+type BundleOptions struct {
+	// StripComponents removes this many leading path elements from each
+	// archive entry before extracting it, mirroring tar's
+	// --strip-components.
+	StripComponents int
+}
+
+// BundleRepoPath returns the repository path for an archived target, e.g.
+// "osqueryd-extensions.tar.gz/linux/stable/osqueryd-extensions.tar.gz" for
+// the linux platform and the stable channel. Unlike RepoPath it does not append
+// constant.ExecutableExtension since the archive filename already carries
+// its own extension.
+func (u *Updater) BundleRepoPath(target, channel string) string {
+	return path.Join(target, u.opt.Platform, channel, target)
+}
+
+// BundleDir returns the local directory an archived target is extracted
+// into by GetBundle.
+func (u *Updater) BundleDir(target, channel string) string {
+	return u.pathFromRoot(binDir, target, u.opt.Platform, channel)
+}
+
+// GetBundle downloads and extracts an archived target (.tar.gz or .zip) —
+// for example an osqueryd bundle shipping extensions, config, and shell
+// completions alongside the main binary — into BundleDir(target, channel).
+// The archive is verified against its TUF hash before extraction. Entries
+// are extracted into a staging directory first and the result is atomically
+// renamed into place, so a reader of BundleDir never observes a partial
+// extraction.
+func (u *Updater) GetBundle(target, channel string, opt BundleOptions) (string, error) {
+	if target == "" {
+		return "", errors.New("target is required")
+	}
+	if channel == "" {
+		return "", errors.New("channel is required")
+	}
+
+	repoPath := u.BundleRepoPath(target, channel)
+	meta, err := u.lookupRepoPath(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	format, err := detectBundleFormat(target, meta)
+	if err != nil {
+		return "", err
+	}
+
+	staging := filepath.Join(u.opt.RootDirectory, stagingDir, "bundle-"+target+"-"+channel)
+	if err := os.RemoveAll(staging); err != nil {
+		return "", errors.Wrap(err, "clear bundle staging dir")
+	}
+	if err := os.MkdirAll(staging, constant.DefaultDirMode); err != nil {
+		return "", errors.Wrap(err, "initialize bundle staging dir")
+	}
+	defer os.RemoveAll(staging)
+
+	archivePath := filepath.Join(staging, filepath.Base(repoPath))
+	archive, err := os.OpenFile(archivePath, os.O_CREATE|os.O_WRONLY, constant.DefaultExecutableMode)
+	if err != nil {
+		return "", errors.Wrap(err, "open temp file for bundle download")
+	}
+	if err := u.client.Download(repoPath, &fileDestination{archive}); err != nil {
+		archive.Close()
+		return "", errors.Wrapf(err, "download bundle %s", repoPath)
+	}
+	if err := archive.Close(); err != nil {
+		return "", errors.Wrap(err, "close bundle download")
+	}
+
+	if err := u.opt.Verifier.Verify(repoPath, *meta, archivePath); err != nil {
+		return "", errors.Wrapf(err, "verify artifact %s", repoPath)
+	}
+
+	extractDir := filepath.Join(staging, "extracted")
+	if err := os.MkdirAll(extractDir, constant.DefaultDirMode); err != nil {
+		return "", errors.Wrap(err, "initialize bundle extract dir")
+	}
+
+	switch format {
+	case bundleFormatTarGz:
+		err = extractTarGz(archivePath, extractDir, opt.StripComponents)
+	case bundleFormatZip:
+		err = extractZip(archivePath, extractDir, opt.StripComponents)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "extract bundle")
+	}
+
+	destDir := u.BundleDir(target, channel)
+	if err := os.MkdirAll(filepath.Dir(destDir), constant.DefaultDirMode); err != nil {
+		return "", errors.Wrap(err, "initialize bundle dest dir")
+	}
+	if err := os.RemoveAll(destDir); err != nil {
+		return "", errors.Wrap(err, "remove previous bundle")
+	}
+	if err := os.Rename(extractDir, destDir); err != nil {
+		return "", errors.Wrap(err, "move bundle into place")
+	}
+
+	return destDir, nil
+}
+
+// detectBundleFormat determines the archive format of a bundled target,
+// preferring an explicit "format" custom metadata field over sniffing the
+// target's file extension.
+func detectBundleFormat(target string, meta *data.TargetFileMeta) (bundleFormat, error) {
+	if meta.Custom != nil {
+		var custom struct {
+			Format string `json:"format"`
+		}
+		if err := json.Unmarshal(*meta.Custom, &custom); err == nil {
+			switch custom.Format {
+			case "tar.gz":
+				return bundleFormatTarGz, nil
+			case "zip":
+				return bundleFormatZip, nil
+			}
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(target, ".tar.gz"), strings.HasSuffix(target, ".tgz"):
+		return bundleFormatTarGz, nil
+	case strings.HasSuffix(target, ".zip"):
+		return bundleFormatZip, nil
+	}
+
+	return bundleFormatUnknown, errors.Errorf("cannot determine archive format for target %q", target)
+}
+
+// extractTarGz extracts a gzip-compressed tarball into destDir.
+func extractTarGz(archivePath, destDir string, stripComponents int) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "open archive")
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrap(err, "open gzip reader")
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read tar entry")
+		}
+
+		// Written by some tar implementations (notably bsdtar/libarchive)
+		// ahead of the first real entry when archiving with pax format;
+		// it carries no file content we want.
+		if path.Base(hdr.Name) == "pax_global_header" {
+			continue
+		}
+
+		name, ok := stripPathComponents(hdr.Name, stripComponents)
+		if !ok {
+			continue
+		}
+
+		dest, err := safeJoin(destDir, name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, constant.DefaultDirMode); err != nil {
+				return errors.Wrap(err, "create directory from archive")
+			}
+		case tar.TypeReg:
+			if err := extractFile(dest, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+			if hdr.FileInfo().Mode()&0o111 != 0 {
+				if err := platform.ChmodExecutable(dest); err != nil {
+					return err
+				}
+			}
+		default:
+			// Symlinks and other entry types are not expected in orbit
+			// bundles and are skipped rather than followed.
+		}
+	}
+
+	return nil
+}
+
+// extractZip extracts a zip archive into destDir.
+func extractZip(archivePath, destDir string, stripComponents int) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "open zip archive")
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if path.Base(f.Name) == "pax_global_header" {
+			continue
+		}
+
+		name, ok := stripPathComponents(f.Name, stripComponents)
+		if !ok {
+			continue
+		}
+
+		dest, err := safeJoin(destDir, name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(dest, constant.DefaultDirMode); err != nil {
+				return errors.Wrap(err, "create directory from archive")
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return errors.Wrap(err, "open zip entry")
+		}
+		err = extractFile(dest, rc, f.Mode())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		if f.Mode()&0o111 != 0 {
+			if err := platform.ChmodExecutable(dest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractFile writes r to path, creating any parent directories needed.
+func extractFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), constant.DefaultDirMode); err != nil {
+		return errors.Wrap(err, "create parent directory")
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Wrap(err, "create extracted file")
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return errors.Wrap(err, "write extracted file")
+	}
+
+	return nil
+}
+
+// stripPathComponents removes the first n slash-separated components of
+// name, reporting ok=false if that would consume the whole path (nothing
+// left to extract).
+func stripPathComponents(name string, n int) (string, bool) {
+	name = filepath.ToSlash(name)
+	parts := strings.Split(name, "/")
+	if len(parts) <= n {
+		return "", false
+	}
+	return filepath.FromSlash(strings.Join(parts[n:], "/")), true
+}
+
+// safeJoin joins name onto base and rejects the result if it would escape
+// base, guarding against path traversal (e.g. "../../etc/passwd") in
+// archive entries — equivalent to requiring Go's tarinsecurepath=0 default.
+func safeJoin(base, name string) (string, error) {
+	cleaned := filepath.Join(base, name)
+	if cleaned != base && !strings.HasPrefix(cleaned, base+string(filepath.Separator)) {
+		return "", errors.Errorf("archive entry %q escapes destination directory", name)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,82 @@
+package update
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	base := filepath.FromSlash("/staging/extracted")
+
+	cases := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{name: "plain file", entry: "osqueryd", wantErr: false},
+		{name: "nested file", entry: "bin/osqueryd", wantErr: false},
+		{name: "parent traversal", entry: "../../etc/passwd", wantErr: true},
+		{name: "absolute path", entry: "/etc/passwd", wantErr: false}, // cleaned relative to base, not escaping
+		{name: "traversal disguised with dot", entry: "bin/../../secrets", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := safeJoin(base, c.entry)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("safeJoin(%q, %q) = %q, want error", base, c.entry, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("safeJoin(%q, %q) returned unexpected error: %v", base, c.entry, err)
+			}
+			if !filepathHasPrefix(got, base) {
+				t.Fatalf("safeJoin(%q, %q) = %q, want path under %q", base, c.entry, got, base)
+			}
+		})
+	}
+}
+
+func filepathHasPrefix(path, base string) bool {
+	if path == base {
+		return true
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !hasDotDotPrefix(rel)
+}
+
+func hasDotDotPrefix(rel string) bool {
+	return len(rel) >= 2 && rel[:2] == ".." && (len(rel) == 2 || rel[2] == filepath.Separator)
+}
+
+func TestStripPathComponents(t *testing.T) {
+	cases := []struct {
+		name   string
+		entry  string
+		strip  int
+		want   string
+		wantOK bool
+	}{
+		{name: "no strip", entry: "bin/osqueryd", strip: 0, want: filepath.FromSlash("bin/osqueryd"), wantOK: true},
+		{name: "strip one", entry: "bundle/bin/osqueryd", strip: 1, want: filepath.FromSlash("bin/osqueryd"), wantOK: true},
+		{name: "strip all", entry: "bundle", strip: 1, want: "", wantOK: false},
+		{name: "strip more than depth", entry: "bundle/bin", strip: 5, want: "", wantOK: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := stripPathComponents(c.entry, c.strip)
+			if ok != c.wantOK {
+				t.Fatalf("stripPathComponents(%q, %d) ok = %v, want %v", c.entry, c.strip, ok, c.wantOK)
+			}
+			if ok && got != c.want {
+				t.Fatalf("stripPathComponents(%q, %d) = %q, want %q", c.entry, c.strip, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package update
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultKey(t *testing.T) {
+	if got, want := resultKey("osqueryd", "stable"), "osqueryd@stable"; got != want {
+		t.Fatalf("resultKey() = %q, want %q", got, want)
+	}
+}
+
+// TestRateLimiterWaitNLargerThanCapacity guards against the bug where a
+// single Write bigger than the configured BytesPerSecond made waitN block
+// forever, since tokens could never exceed capacity.
+func TestRateLimiterWaitNLargerThanCapacity(t *testing.T) {
+	const bytesPerSecond = 16 * 1024 // 16KB/s, smaller than a typical 32KB copy buffer
+	limiter := newRateLimiter(bytesPerSecond)
+
+	done := make(chan struct{})
+	go func() {
+		limiter.waitN(32 * 1024) // one write, double the bucket's capacity
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitN did not return for a write larger than the bucket's capacity")
+	}
+}
+
+func TestRateLimiterWaitNThrottles(t *testing.T) {
+	const bytesPerSecond = 1024 * 1024 // 1MB/s
+	limiter := newRateLimiter(bytesPerSecond)
+
+	// First call is served from the initial full bucket and should return
+	// immediately.
+	start := time.Now()
+	limiter.waitN(bytesPerSecond)
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("first waitN took %v, want close to instant (bucket starts full)", elapsed)
+	}
+
+	// A second call for the same amount must wait for the bucket to refill,
+	// i.e. roughly another second.
+	start = time.Now()
+	limiter.waitN(bytesPerSecond)
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("second waitN took %v, want it to wait for refill", elapsed)
+	}
+}
+
+func TestRateLimiterNilIsNoop(t *testing.T) {
+	var limiter *rateLimiter
+	done := make(chan struct{})
+	go func() {
+		limiter.waitN(1 << 30)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waitN on a nil *rateLimiter should be a no-op")
+	}
+}
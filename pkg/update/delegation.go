@@ -0,0 +1,113 @@
+package update
+
+import (
+	"path"
+	"strings"
+
+	"github.com/fleetdm/orbit/pkg/constant"
+	"github.com/pkg/errors"
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// DelegatedRole describes a TUF delegated targets role used to namespace
+// targets for a particular product or tenant, e.g. "orbit", "osqueryd", or a
+// third-party extension, so each can be signed by its own delegated keys
+// instead of the single top-level targets role.
+type DelegatedRole struct {
+	// Name is the delegated role name as it appears in the TUF targets
+	// delegation tree (e.g. "osqueryd").
+	Name string
+	// Paths restricts which target path patterns this role is responsible
+	// for, using the same shell glob syntax as the TUF delegation "paths"
+	// field. Left empty, the role is assumed to own everything under its
+	// own namespace.
+	Paths []string
+}
+
+// RepoPathForRole builds the repository-relative path for a target signed
+// under a specific delegated role, namespacing it under the role name ahead
+// of the usual target/platform/channel layout used by RepoPath.
+func (u *Updater) RepoPathForRole(role, target, channel string) string {
+	return path.Join(role, target, u.opt.Platform, channel, target+constant.ExecutableExtension(u.opt.Platform))
+}
+
+// Delegations returns the delegated targets roles this Updater has been
+// configured to recognize, in addition to the top-level targets role.
+func (u *Updater) Delegations() []DelegatedRole {
+	return u.opt.Delegations
+}
+
+// findDelegation returns the DelegatedRole registered under role, or nil if
+// it is not known.
+func (u *Updater) findDelegation(role string) *DelegatedRole {
+	for i, d := range u.opt.Delegations {
+		if d.Name == role {
+			return &u.opt.Delegations[i]
+		}
+	}
+	return nil
+}
+
+// matchesPaths reports whether repoPath is covered by paths, using the same
+// shell glob syntax as the TUF delegation "paths" field. An empty paths
+// list matches everything, consistent with a role that owns its entire
+// namespace.
+func matchesPaths(paths []string, repoPath string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, pattern := range paths {
+		if ok, err := path.Match(pattern, repoPath); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LookupDelegated looks up target metadata under the specified delegated
+// role rather than the top-level targets role, resolving it through
+// go-tuf's normal delegation traversal. This should be called after
+// UpdateMetadata.
+func (u *Updater) LookupDelegated(role, target, channel string) (*data.TargetFileMeta, error) {
+	d := u.findDelegation(role)
+	if d == nil {
+		return nil, errors.Errorf("unknown delegated role %q", role)
+	}
+
+	repoPath := u.RepoPathForRole(role, target, channel)
+	if !matchesPaths(d.Paths, repoPath) {
+		return nil, errors.Errorf("target %q is outside role %q's delegated paths", repoPath, role)
+	}
+
+	t, err := u.client.Target(repoPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lookup %s@%s in role %s", target, channel, role)
+	}
+
+	return &t, nil
+}
+
+// TargetsForRole returns the subset of known targets (as returned by
+// Targets) whose repository path is namespaced under the given delegated
+// role and covered by its Paths.
+func (u *Updater) TargetsForRole(role string) (data.TargetFiles, error) {
+	d := u.findDelegation(role)
+	if d == nil {
+		return nil, errors.Errorf("unknown delegated role %q", role)
+	}
+
+	all, err := u.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := role + "/"
+	filtered := make(data.TargetFiles, len(all))
+	for p, meta := range all {
+		if strings.HasPrefix(p, prefix) && matchesPaths(d.Paths, p) {
+			filtered[p] = meta
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,60 @@
+package update
+
+import (
+	"testing"
+)
+
+func TestMatchesPaths(t *testing.T) {
+	cases := []struct {
+		name     string
+		paths    []string
+		repoPath string
+		want     bool
+	}{
+		{name: "empty paths matches everything", paths: nil, repoPath: "osqueryd/linux/stable/osqueryd", want: true},
+		{name: "exact match", paths: []string{"osqueryd/*/*/osqueryd"}, repoPath: "osqueryd/linux/stable/osqueryd", want: true},
+		{name: "no match", paths: []string{"osqueryd/*/*/osqueryd"}, repoPath: "orbit/linux/stable/orbit", want: false},
+		{name: "one of several patterns matches", paths: []string{"orbit/*", "osqueryd/*/*/osqueryd"}, repoPath: "osqueryd/linux/stable/osqueryd", want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchesPaths(c.paths, c.repoPath); got != c.want {
+				t.Fatalf("matchesPaths(%v, %q) = %v, want %v", c.paths, c.repoPath, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLookupDelegatedRejectsOutOfPathTarget(t *testing.T) {
+	u := &Updater{
+		opt: Options{
+			Platform: "linux",
+			Delegations: []DelegatedRole{
+				{Name: "osqueryd", Paths: []string{"osqueryd/*/*/osqueryd"}},
+			},
+		},
+	}
+
+	// "nudge" doesn't match the osqueryd role's declared paths, so this
+	// must be rejected before ever touching u.client (which is nil here).
+	if _, err := u.LookupDelegated("osqueryd", "nudge", "stable"); err == nil {
+		t.Fatal("LookupDelegated succeeded for a target outside the role's declared paths")
+	}
+}
+
+func TestLookupDelegatedUnknownRole(t *testing.T) {
+	u := &Updater{opt: Options{Platform: "linux"}}
+
+	if _, err := u.LookupDelegated("osqueryd", "osqueryd", "stable"); err == nil {
+		t.Fatal("LookupDelegated succeeded for an unregistered role")
+	}
+}
+
+func TestTargetsForRoleUnknownRole(t *testing.T) {
+	u := &Updater{opt: Options{Platform: "linux"}}
+
+	if _, err := u.TargetsForRole("osqueryd"); err == nil {
+		t.Fatal("TargetsForRole succeeded for an unregistered role")
+	}
+}
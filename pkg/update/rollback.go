@@ -0,0 +1,113 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// previousSuffix is appended to a target's local path to retain the
+// last-known-good binary across an update, so Rollback has something to
+// restore.
+const previousSuffix = ".previous"
+
+// previousPath returns the path at which the last-known-good binary for
+// target/channel is retained.
+func (u *Updater) previousPath(target, channel string) string {
+	return u.LocalPath(target, channel) + previousSuffix
+}
+
+// Rollback restores the last-known-good binary for target/channel,
+// verifying its TUF hash against the cached target metadata before
+// swapping it back into place. It returns an error if no previous binary
+// was retained or if it no longer matches the metadata on record.
+func (u *Updater) Rollback(target, channel string) error {
+	localPath := u.LocalPath(target, channel)
+	previous := u.previousPath(target, channel)
+
+	stat, err := os.Stat(previous)
+	if err != nil {
+		return errors.Wrap(err, "stat previous binary")
+	}
+	if !stat.Mode().IsRegular() {
+		return errors.Errorf("expected %s to be regular file", previous)
+	}
+
+	if meta, err := u.Lookup(target, channel); err != nil {
+		log.Debug().Err(err).Msg("no cached target metadata, skipping hash check on rollback")
+	} else if err := CheckFileHash(meta, previous); err != nil {
+		return errors.Wrap(err, "previous binary failed hash verification")
+	}
+
+	// Rename the current binary out of the way before renaming previous
+	// into place, same as Download's retention swap: on Windows a running
+	// executable can't simply be overwritten in one step.
+	displaced := localPath + ".rollback"
+	if err := os.Rename(localPath, displaced); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "move aside current binary")
+	}
+
+	if err := os.Rename(previous, localPath); err != nil {
+		return errors.Wrap(err, "restore previous binary")
+	}
+
+	if err := os.Remove(displaced); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Debug().Err(err).Str("path", displaced).Msg("failed to clean up displaced binary after rollback")
+	}
+
+	log.Info().Str("target", target).Str("channel", channel).Msg("rolled back to previous binary")
+
+	return nil
+}
+
+// PruneOld removes retained .previous binaries under the bin directory.
+// Orbit currently only ever retains a single previous generation per
+// target/channel, so PruneOld(0) deletes it while PruneOld(keep) for
+// keep >= 1 is a no-op, reserved for future multi-generation retention.
+func (u *Updater) PruneOld(keep int) error {
+	if keep >= 1 {
+		return nil
+	}
+
+	root := u.pathFromRoot(binDir)
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(p) != previousSuffix {
+			return nil
+		}
+		if rmErr := os.Remove(p); rmErr != nil {
+			return errors.Wrapf(rmErr, "prune %s", p)
+		}
+		return nil
+	})
+}
+
+// StartHealthWatch runs opt.HealthCheck, if configured, after
+// opt.RollbackGracePeriod has elapsed and automatically rolls back
+// target/channel if it reports an error. It returns immediately; the check
+// runs in the background. Callers typically invoke this once after
+// restarting into a newly downloaded binary.
+func (u *Updater) StartHealthWatch(target, channel string) {
+	if u.opt.HealthCheck == nil {
+		return
+	}
+
+	go func() {
+		time.Sleep(u.opt.RollbackGracePeriod)
+
+		if err := u.opt.HealthCheck(target, channel); err != nil {
+			log.Error().Err(err).Str("target", target).Str("channel", channel).Msg("health check failed, rolling back")
+			if rbErr := u.Rollback(target, channel); rbErr != nil {
+				log.Error().Err(rbErr).Msg("automatic rollback failed")
+			}
+		}
+	}()
+}
@@ -0,0 +1,129 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// TrustedRoot returns the currently trusted root metadata, for inspection or
+// to compare against a candidate root before calling RotateRoot.
+func (u *Updater) TrustedRoot() (*data.Root, error) {
+	meta, err := u.opt.LocalStore.GetMeta()
+	if err != nil {
+		return nil, errors.Wrap(err, "read local metadata store")
+	}
+
+	rootJSON, ok := meta["root.json"]
+	if !ok {
+		return nil, errors.New("no trusted root metadata available")
+	}
+
+	var signedRoot data.Signed
+	if err := json.Unmarshal(rootJSON, &signedRoot); err != nil {
+		return nil, errors.Wrap(err, "unmarshal trusted root")
+	}
+
+	var root data.Root
+	if err := json.Unmarshal(signedRoot.Signed, &root); err != nil {
+		return nil, errors.Wrap(err, "unmarshal trusted root")
+	}
+
+	return &root, nil
+}
+
+// RotateRoot validates newRootJSON as the next version of root metadata
+// and, if it is properly signed by the currently trusted root keys,
+// persists it via LocalStore.SetMeta. Per TUF §5.3, root updates must
+// advance one version at a time, so a caller skipping several root versions
+// (e.g. recovering from a long-expired pinned root) should call RotateRoot
+// once per intermediate root.json in sequence; a multi-version jump in a
+// single call is rejected rather than silently trusted.
+func (u *Updater) RotateRoot(newRootJSON []byte) error {
+	trusted, err := u.TrustedRoot()
+	if err != nil {
+		return errors.Wrap(err, "load trusted root")
+	}
+
+	var signedRoot data.Signed
+	if err := json.Unmarshal(newRootJSON, &signedRoot); err != nil {
+		return errors.Wrap(err, "unmarshal new root")
+	}
+
+	newRoot, err := verifyRootSignedBy(trusted, &signedRoot)
+	if err != nil {
+		return errors.Wrap(err, "verify new root")
+	}
+
+	if newRoot.Version != trusted.Version+1 {
+		return errors.Errorf(
+			"root version must advance by exactly one (trusted=%d, new=%d); rotate through each intermediate root.json in sequence per TUF §5.3",
+			trusted.Version, newRoot.Version,
+		)
+	}
+
+	if time.Now().After(newRoot.Expires) {
+		return errors.Errorf("new root expired at %s, refusing to trust it", newRoot.Expires)
+	}
+
+	if err := u.opt.LocalStore.SetMeta("root.json", newRootJSON); err != nil {
+		return errors.Wrap(err, "persist rotated root")
+	}
+
+	log.Info().
+		Int("previous_version", trusted.Version).
+		Int("new_version", newRoot.Version).
+		Msg("rotated trusted TUF root")
+
+	return nil
+}
+
+// verifyRootSignedBy checks that signedRoot carries signatures from at
+// least trusted's root role threshold of trusted's root role keys, and
+// returns the parsed new root on success.
+func verifyRootSignedBy(trusted *data.Root, signedRoot *data.Signed) (*data.Root, error) {
+	rootRole, ok := trusted.Roles["root"]
+	if !ok {
+		return nil, errors.New("trusted root metadata has no root role")
+	}
+
+	trustedKeys := make(map[string]ed25519.PublicKey, len(rootRole.KeyIDs))
+	for _, id := range rootRole.KeyIDs {
+		key, ok := trusted.Keys[id]
+		if !ok || key.Type != "ed25519" {
+			continue
+		}
+		trustedKeys[id] = ed25519.PublicKey(key.Value.Public)
+	}
+
+	valid := 0
+	seen := make(map[string]bool, len(signedRoot.Signatures))
+	for _, sig := range signedRoot.Signatures {
+		if seen[sig.KeyID] {
+			continue
+		}
+		pub, ok := trustedKeys[sig.KeyID]
+		if !ok {
+			continue
+		}
+		if ed25519.Verify(pub, signedRoot.Signed, sig.Signature) {
+			seen[sig.KeyID] = true
+			valid++
+		}
+	}
+
+	if valid < rootRole.Threshold {
+		return nil, errors.Errorf("new root has %d valid signature(s) from trusted keys, threshold requires %d", valid, rootRole.Threshold)
+	}
+
+	var newRoot data.Root
+	if err := json.Unmarshal(signedRoot.Signed, &newRoot); err != nil {
+		return nil, errors.Wrap(err, "unmarshal new root")
+	}
+
+	return &newRoot, nil
+}
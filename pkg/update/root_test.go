@@ -0,0 +1,183 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// memLocalStore is a minimal in-memory client.LocalStore for exercising
+// RotateRoot/TrustedRoot without a real TUF client.
+type memLocalStore struct {
+	meta map[string]json.RawMessage
+}
+
+func newMemLocalStore(rootJSON []byte) *memLocalStore {
+	return &memLocalStore{meta: map[string]json.RawMessage{"root.json": rootJSON}}
+}
+
+func (s *memLocalStore) GetMeta() (map[string]json.RawMessage, error) {
+	return s.meta, nil
+}
+
+func (s *memLocalStore) SetMeta(name string, meta json.RawMessage) error {
+	s.meta[name] = meta
+	return nil
+}
+
+func (s *memLocalStore) DeleteMeta(name string) error {
+	delete(s.meta, name)
+	return nil
+}
+
+func (s *memLocalStore) Close() error {
+	return nil
+}
+
+// mustSignRoot is signRoot plus json.Marshal, for tests that need the raw
+// bytes RotateRoot/LocalStore deal in rather than the *data.Signed value.
+func mustSignRoot(t *testing.T, root *data.Root, signerIDs []string, signers []ed25519.PrivateKey) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(signRoot(t, root, signerIDs, signers))
+	if err != nil {
+		t.Fatalf("marshal signed root: %v", err)
+	}
+	return b
+}
+
+// newTestRoot builds a minimal, unsigned root metadata value whose "root"
+// role trusts the given public keys under the given threshold; sign it with
+// signRoot or mustSignRoot.
+func newTestRoot(t *testing.T, version int, keys []ed25519.PublicKey, threshold int) *data.Root {
+	t.Helper()
+
+	root := &data.Root{
+		Version: version,
+		Keys:    make(map[string]*data.Key, len(keys)),
+		Roles:   make(map[string]*data.Role, 1),
+	}
+
+	var keyIDs []string
+	for i, pub := range keys {
+		id := string(rune('a' + i))
+		root.Keys[id] = &data.Key{
+			Type:  "ed25519",
+			Value: data.KeyValue{Public: data.HexBytes(pub)},
+		}
+		keyIDs = append(keyIDs, id)
+	}
+	root.Roles["root"] = &data.Role{KeyIDs: keyIDs, Threshold: threshold}
+
+	return root
+}
+
+// signRoot marshals root and signs it with signers, returning a data.Signed
+// ready to hand to verifyRootSignedBy.
+func signRoot(t *testing.T, root *data.Root, signerIDs []string, signers []ed25519.PrivateKey) *data.Signed {
+	t.Helper()
+
+	payload, err := json.Marshal(root)
+	if err != nil {
+		t.Fatalf("marshal root: %v", err)
+	}
+
+	signed := &data.Signed{Signed: payload}
+	for i, priv := range signers {
+		signed.Signatures = append(signed.Signatures, data.Signature{
+			KeyID:     signerIDs[i],
+			Signature: ed25519.Sign(priv, payload),
+		})
+	}
+
+	return signed
+}
+
+func TestVerifyRootSignedByThreshold(t *testing.T) {
+	pubA, privA, _ := ed25519.GenerateKey(nil)
+	pubB, privB, _ := ed25519.GenerateKey(nil)
+	pubC, privC, _ := ed25519.GenerateKey(nil)
+	_, privUntrusted, _ := ed25519.GenerateKey(nil)
+
+	trusted := newTestRoot(t, 1, []ed25519.PublicKey{pubA, pubB, pubC}, 2)
+	newRoot := newTestRoot(t, 2, []ed25519.PublicKey{pubA, pubB, pubC}, 2)
+
+	t.Run("meets threshold", func(t *testing.T) {
+		signed := signRoot(t, newRoot, []string{"a", "b"}, []ed25519.PrivateKey{privA, privB})
+		got, err := verifyRootSignedBy(trusted, signed)
+		if err != nil {
+			t.Fatalf("verifyRootSignedBy returned unexpected error: %v", err)
+		}
+		if got.Version != 2 {
+			t.Fatalf("got version %d, want 2", got.Version)
+		}
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		signed := signRoot(t, newRoot, []string{"a"}, []ed25519.PrivateKey{privA})
+		if _, err := verifyRootSignedBy(trusted, signed); err == nil {
+			t.Fatal("verifyRootSignedBy succeeded with only 1 of 2 required signatures")
+		}
+	})
+
+	t.Run("duplicate signatures from the same key don't count twice", func(t *testing.T) {
+		signed := signRoot(t, newRoot, []string{"a", "a"}, []ed25519.PrivateKey{privA, privA})
+		if _, err := verifyRootSignedBy(trusted, signed); err == nil {
+			t.Fatal("verifyRootSignedBy succeeded with duplicate signatures from a single key")
+		}
+	})
+
+	t.Run("signatures from untrusted keys don't count", func(t *testing.T) {
+		signed := signRoot(t, newRoot, []string{"a", "z"}, []ed25519.PrivateKey{privA, privUntrusted})
+		if _, err := verifyRootSignedBy(trusted, signed); err == nil {
+			t.Fatal("verifyRootSignedBy succeeded with a signature from a key outside the trusted set")
+		}
+	})
+}
+
+func TestRotateRootRejectsExpiredRoot(t *testing.T) {
+	pubA, privA, _ := ed25519.GenerateKey(nil)
+
+	trusted := newTestRoot(t, 1, []ed25519.PublicKey{pubA}, 1)
+	trusted.Expires = time.Now().Add(time.Hour)
+	trustedJSON := mustSignRoot(t, trusted, []string{"a"}, []ed25519.PrivateKey{privA})
+
+	expired := newTestRoot(t, 2, []ed25519.PublicKey{pubA}, 1)
+	expired.Expires = time.Now().Add(-time.Hour)
+	expiredJSON := mustSignRoot(t, expired, []string{"a"}, []ed25519.PrivateKey{privA})
+
+	u := &Updater{opt: Options{LocalStore: newMemLocalStore(trustedJSON)}}
+
+	if err := u.RotateRoot(expiredJSON); err == nil {
+		t.Fatal("RotateRoot succeeded with an already-expired candidate root")
+	}
+}
+
+func TestRotateRootAcceptsUnexpiredRoot(t *testing.T) {
+	pubA, privA, _ := ed25519.GenerateKey(nil)
+
+	trusted := newTestRoot(t, 1, []ed25519.PublicKey{pubA}, 1)
+	trusted.Expires = time.Now().Add(time.Hour)
+	trustedJSON := mustSignRoot(t, trusted, []string{"a"}, []ed25519.PrivateKey{privA})
+
+	next := newTestRoot(t, 2, []ed25519.PublicKey{pubA}, 1)
+	next.Expires = time.Now().Add(time.Hour)
+	nextJSON := mustSignRoot(t, next, []string{"a"}, []ed25519.PrivateKey{privA})
+
+	u := &Updater{opt: Options{LocalStore: newMemLocalStore(trustedJSON)}}
+
+	if err := u.RotateRoot(nextJSON); err != nil {
+		t.Fatalf("RotateRoot returned unexpected error: %v", err)
+	}
+
+	got, err := u.TrustedRoot()
+	if err != nil {
+		t.Fatalf("TrustedRoot: %v", err)
+	}
+	if got.Version != 2 {
+		t.Fatalf("got version %d, want 2", got.Version)
+	}
+}
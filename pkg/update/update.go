@@ -2,6 +2,7 @@
 package update
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/fleetdm/orbit/pkg/constant"
 	"github.com/fleetdm/orbit/pkg/platform"
@@ -53,6 +55,33 @@ type Options struct {
 	OrbitChannel string
 	// OsquerydChannel is the update channel to use for osquery (osqueryd).
 	OsquerydChannel string
+	// Delegations lists the TUF delegated targets roles this Updater
+	// recognizes, allowing different products or tenants to be signed by
+	// separate delegated keys instead of the single top-level targets
+	// role. See LookupDelegated.
+	Delegations []DelegatedRole
+	// HealthCheck, if set, is invoked by StartHealthWatch after
+	// RollbackGracePeriod has elapsed. Returning an error triggers an
+	// automatic Rollback for the target/channel passed to
+	// StartHealthWatch.
+	HealthCheck func(target, channel string) error
+	// RollbackGracePeriod is how long StartHealthWatch waits before
+	// running HealthCheck, giving a freshly installed binary time to
+	// start up.
+	RollbackGracePeriod time.Duration
+	// Verifier is run against every downloaded artifact in addition to
+	// go-tuf's own hash check. Defaults to TUFVerifier (a no-op) if nil.
+	// Set it to a *DistsignVerifier to layer an additional signature
+	// scheme on top of TUF.
+	Verifier ArtifactVerifier
+	// SigningKeyURL is wired into opt.Verifier when it is a
+	// *DistsignVerifier that doesn't already have one set.
+	SigningKeyURL string
+	// RootKeysPath, if set, is the path to a full signed root.json used to
+	// bootstrap trust instead of RootKeys, so threshold and expiration are
+	// honored rather than trusting a bare list of keys. Only consulted
+	// when no local root metadata exists yet.
+	RootKeysPath string
 }
 
 // New creates a new updater given the provided options. All the necessary
@@ -70,19 +99,36 @@ func New(opt Options) (*Updater, error) {
 	}
 
 	tufClient := client.NewClient(opt.LocalStore, remoteStore)
-	var rootKeys []*data.Key
-	if err := json.Unmarshal([]byte(opt.RootKeys), &rootKeys); err != nil {
-		return nil, errors.Wrap(err, "unmarshal root keys")
-	}
 
 	meta, err := opt.LocalStore.GetMeta()
 	if err != nil || meta["root.json"] == nil {
-		var rootKeys []*data.Key
-		if err := json.Unmarshal([]byte(opt.RootKeys), &rootKeys); err != nil {
-			return nil, errors.Wrap(err, "unmarshal root keys")
+		if opt.RootKeysPath != "" {
+			rootJSON, err := os.ReadFile(opt.RootKeysPath)
+			if err != nil {
+				return nil, errors.Wrap(err, "read pinned root")
+			}
+			if err := opt.LocalStore.SetMeta("root.json", rootJSON); err != nil {
+				return nil, errors.Wrap(err, "persist pinned root")
+			}
+		} else {
+			var rootKeys []*data.Key
+			if err := json.Unmarshal([]byte(opt.RootKeys), &rootKeys); err != nil {
+				return nil, errors.Wrap(err, "unmarshal root keys")
+			}
+			if err := tufClient.Init(rootKeys, 1); err != nil {
+				return nil, errors.Wrap(err, "init tuf client")
+			}
 		}
-		if err := tufClient.Init(rootKeys, 1); err != nil {
-			return nil, errors.Wrap(err, "init tuf client")
+	}
+
+	if opt.Verifier == nil {
+		opt.Verifier = TUFVerifier{}
+	}
+	if dv, ok := opt.Verifier.(*DistsignVerifier); ok {
+		dv.httpClient = httpClient
+		dv.serverURL = opt.ServerURL
+		if dv.SigningKeyURL == "" {
+			dv.SigningKeyURL = opt.SigningKeyURL
 		}
 	}
 
@@ -120,11 +166,23 @@ func (u *Updater) LocalPath(target, channel string) string {
 // Lookup looks up the provided target in the local target metadata. This should
 // be called after UpdateMetadata.
 func (u *Updater) Lookup(target, channel string) (*data.TargetFileMeta, error) {
-	t, err := u.client.Target(u.RepoPath(target, channel))
+	meta, err := u.lookupRepoPath(u.RepoPath(target, channel))
 	if err != nil {
 		return nil, errors.Wrapf(err, "lookup %s@%s", target, channel)
 	}
 
+	return meta, nil
+}
+
+// lookupRepoPath looks up target metadata by its raw repository path,
+// without assuming the target/platform/channel/executable layout RepoPath
+// builds.
+func (u *Updater) lookupRepoPath(repoPath string) (*data.TargetFileMeta, error) {
+	t, err := u.client.Target(repoPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lookup %s", repoPath)
+	}
+
 	return &t, nil
 }
 
@@ -177,6 +235,26 @@ func (u *Updater) Get(target, channel string) (string, error) {
 // Download downloads the target to the provided path. The file is deleted and
 // an error is returned if the hash does not match.
 func (u *Updater) Download(repoPath, localPath string) error {
+	return u.download(context.Background(), repoPath, localPath, "", nil)
+}
+
+// download is the shared implementation behind Download and GetAll's
+// getWithProgress: it stages the download, hash-verifies it (via go-tuf),
+// optionally wraps the destination (for progress reporting/rate limiting),
+// runs opt.Verifier, self-tests the new binary, and atomically swaps the
+// result into localPath, retaining the displaced binary as
+// "<target>.previous".
+//
+// tmpSuffix distinguishes the staging filename used by concurrent callers
+// (GetAll) from the single-target Download/Get path, so the two can never
+// collide on the same staging file for the same target. wrap, if non-nil,
+// is given the plain file destination and the resolved target metadata and
+// may return a wrapped client.Destination around it.
+func (u *Updater) download(ctx context.Context, repoPath, localPath, tmpSuffix string, wrap func(client.Destination, data.TargetFileMeta) client.Destination) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	staging := filepath.Join(u.opt.RootDirectory, stagingDir)
 
 	if err := os.MkdirAll(staging, constant.DefaultDirMode); err != nil {
@@ -190,7 +268,7 @@ func (u *Updater) Download(repoPath, localPath string) error {
 	}
 
 	tmp, err := os.OpenFile(
-		filepath.Join(staging, filepath.Base(localPath)),
+		filepath.Join(staging, filepath.Base(localPath)+tmpSuffix),
 		os.O_CREATE|os.O_WRONLY,
 		constant.DefaultExecutableMode,
 	)
@@ -215,14 +293,27 @@ func (u *Updater) Download(repoPath, localPath string) error {
 		return err
 	}
 
+	meta, err := u.client.Target(repoPath)
+	if err != nil {
+		return errors.Wrapf(err, "lookup metadata for %s", repoPath)
+	}
+
 	// The go-tuf client handles checking of max size and hash.
-	if err := u.client.Download(repoPath, &fileDestination{tmp}); err != nil {
+	var dest client.Destination = &fileDestination{tmp}
+	if wrap != nil {
+		dest = wrap(dest, meta)
+	}
+	if err := u.client.Download(repoPath, dest); err != nil {
 		return errors.Wrapf(err, "download target %s", repoPath)
 	}
 	if err := tmp.Close(); err != nil {
 		return errors.Wrap(err, "close tmp file")
 	}
 
+	if err := u.opt.Verifier.Verify(repoPath, meta, tmp.Name()); err != nil {
+		return errors.Wrapf(err, "verify artifact %s", repoPath)
+	}
+
 	// Attempt to exec the new binary only if the platform matches. This will
 	// always fail if the binary doesn't match the platform, so there's not
 	// really anything we can check.
@@ -233,11 +324,12 @@ func (u *Updater) Download(repoPath, localPath string) error {
 		}
 	}
 
-	if constant.PlatformName == "windows" {
-		// Remove old file first
-		if err := os.Rename(localPath, localPath+".old"); err != nil && !errors.Is(err, os.ErrNotExist) {
-			return errors.Wrap(err, "rename old")
-		}
+	// Retain the previous binary as <target>.previous so Rollback has
+	// something to restore if the new one turns out to be bad. This also
+	// does double duty as the rename-before-replace dance Windows
+	// requires since it can't overwrite a running executable.
+	if err := os.Rename(localPath, localPath+previousSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "retain previous binary")
 	}
 
 	if err := os.Rename(tmp.Name(), localPath); err != nil {
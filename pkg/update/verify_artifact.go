@@ -0,0 +1,142 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// ArtifactVerifier is a pluggable check run against a downloaded artifact
+// before Download installs it, on top of the hash check go-tuf's client
+// already performs while streaming the download. A failed Verify is treated
+// exactly like a TUF hash mismatch: the temp file is deleted and the error
+// is surfaced to the caller.
+type ArtifactVerifier interface {
+	// Verify is called with the repository path of the target, the TUF
+	// metadata go-tuf resolved for it, and the local path of the
+	// already-downloaded (but not yet installed) file.
+	Verify(repoPath string, meta data.TargetFileMeta, localPath string) error
+}
+
+// TUFVerifier is the default ArtifactVerifier. go-tuf's client already
+// checks the target hash while streaming the download, so this is a no-op,
+// kept around so Options.Verifier always has a concrete, explicit default.
+type TUFVerifier struct{}
+
+// Verify implements ArtifactVerifier.
+func (TUFVerifier) Verify(repoPath string, meta data.TargetFileMeta, localPath string) error {
+	return nil
+}
+
+// signedKey is a rotating signing key, itself signed by the long-lived
+// distsign root key, as served from DistsignVerifier.SigningKeyURL.
+type signedKey struct {
+	Key       string `json:"key"`       // base64 ed25519 public key
+	Signature string `json:"signature"` // base64 signature over Key by the root key
+}
+
+// DistsignVerifier layers a two-key signing scheme on top of TUF: a
+// long-lived root key signs a rotating signing key fetched from
+// SigningKeyURL, and the signing key signs each artifact. The detached
+// signature for a target is fetched from "<repoPath>.sig" alongside the
+// target itself. This gives operators a second, independent signature
+// system for defense in depth.
+type DistsignVerifier struct {
+	// RootKey is the long-lived ed25519 public key that signs rotating
+	// signing keys.
+	RootKey ed25519.PublicKey
+	// SigningKeyURL is fetched, relative to the update server, to obtain
+	// the current signing key and its signature by RootKey.
+	SigningKeyURL string
+
+	httpClient *http.Client
+	serverURL  string
+}
+
+// Verify implements ArtifactVerifier.
+func (v *DistsignVerifier) Verify(repoPath string, meta data.TargetFileMeta, localPath string) error {
+	signingKey, err := v.fetchSigningKey()
+	if err != nil {
+		return errors.Wrap(err, "fetch signing key")
+	}
+
+	sig, err := v.fetchSignature(repoPath)
+	if err != nil {
+		return errors.Wrap(err, "fetch artifact signature")
+	}
+
+	contents, err := os.ReadFile(localPath)
+	if err != nil {
+		return errors.Wrap(err, "read artifact for signature verification")
+	}
+
+	if !ed25519.Verify(signingKey, contents, sig) {
+		return errors.New("artifact signature verification failed")
+	}
+
+	return nil
+}
+
+func (v *DistsignVerifier) fetchSigningKey() (ed25519.PublicKey, error) {
+	resp, err := v.client().Get(v.serverURL + "/" + v.SigningKeyURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch signing key: unexpected status %s", resp.Status)
+	}
+
+	var sk signedKey
+	if err := json.NewDecoder(resp.Body).Decode(&sk); err != nil {
+		return nil, errors.Wrap(err, "decode signing key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(sk.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode signing key bytes")
+	}
+	sig, err := base64.StdEncoding.DecodeString(sk.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode signing key signature")
+	}
+
+	if !ed25519.Verify(v.RootKey, key, sig) {
+		return nil, errors.New("signing key failed root signature verification")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+func (v *DistsignVerifier) fetchSignature(repoPath string) ([]byte, error) {
+	resp, err := v.client().Get(v.serverURL + "/targets/" + repoPath + ".sig")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch artifact signature: unexpected status %s", resp.Status)
+	}
+
+	encoded, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(string(encoded))
+}
+
+func (v *DistsignVerifier) client() *http.Client {
+	if v.httpClient != nil {
+		return v.httpClient
+	}
+	return http.DefaultClient
+}
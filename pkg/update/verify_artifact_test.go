@@ -0,0 +1,115 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+// newDistsignTestServer serves a signing key (signed by rootPriv) at
+// "/signing-key.json" and each detached artifact signature in signatures at
+// "/targets/<repoPath>.sig", so DistsignVerifier.Verify can be
+// exercised end to end against a stub server.
+func newDistsignTestServer(t *testing.T, rootPriv ed25519.PrivateKey, signingPub ed25519.PublicKey, signatures map[string][]byte) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/signing-key.json", func(w http.ResponseWriter, r *http.Request) {
+		key := base64.StdEncoding.EncodeToString(signingPub)
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(rootPriv, signingPub))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key":"` + key + `","signature":"` + sig + `"}`))
+	})
+	for repoPath, sig := range signatures {
+		sig := sig
+		mux.HandleFunc("/targets/"+repoPath+".sig", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(base64.StdEncoding.EncodeToString(sig)))
+		})
+	}
+
+	return httptest.NewServer(mux)
+}
+
+func writeTempArtifact(t *testing.T, contents []byte) string {
+	t.Helper()
+
+	f, err := os.CreateTemp(t.TempDir(), "artifact")
+	if err != nil {
+		t.Fatalf("create temp artifact: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(contents); err != nil {
+		t.Fatalf("write temp artifact: %v", err)
+	}
+	return f.Name()
+}
+
+func TestDistsignVerifierVerify(t *testing.T) {
+	rootPub, rootPriv, _ := ed25519.GenerateKey(nil)
+	signingPub, signingPriv, _ := ed25519.GenerateKey(nil)
+
+	artifact := []byte("osqueryd binary contents")
+	localPath := writeTempArtifact(t, artifact)
+	repoPath := "osqueryd/linux/stable/osqueryd"
+
+	t.Run("valid signature succeeds", func(t *testing.T) {
+		sig := ed25519.Sign(signingPriv, artifact)
+		srv := newDistsignTestServer(t, rootPriv, signingPub, map[string][]byte{repoPath: sig})
+		defer srv.Close()
+
+		v := &DistsignVerifier{RootKey: rootPub, SigningKeyURL: "signing-key.json", serverURL: srv.URL}
+		if err := v.Verify(repoPath, data.TargetFileMeta{}, localPath); err != nil {
+			t.Fatalf("Verify returned unexpected error: %v", err)
+		}
+	})
+
+	t.Run("tampered signature fails", func(t *testing.T) {
+		_, otherPriv, _ := ed25519.GenerateKey(nil)
+		badSig := ed25519.Sign(otherPriv, artifact)
+		srv := newDistsignTestServer(t, rootPriv, signingPub, map[string][]byte{repoPath: badSig})
+		defer srv.Close()
+
+		v := &DistsignVerifier{RootKey: rootPub, SigningKeyURL: "signing-key.json", serverURL: srv.URL}
+		if err := v.Verify(repoPath, data.TargetFileMeta{}, localPath); err == nil {
+			t.Fatal("Verify succeeded with a signature from an untrusted key")
+		}
+	})
+
+	t.Run("non-200 signing key response fails", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/signing-key.json", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		v := &DistsignVerifier{RootKey: rootPub, SigningKeyURL: "signing-key.json", serverURL: srv.URL}
+		if err := v.Verify(repoPath, data.TargetFileMeta{}, localPath); err == nil {
+			t.Fatal("Verify succeeded despite a 404 fetching the signing key")
+		}
+	})
+
+	t.Run("non-200 artifact signature response fails", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/signing-key.json", func(w http.ResponseWriter, r *http.Request) {
+			key := base64.StdEncoding.EncodeToString(signingPub)
+			sig := base64.StdEncoding.EncodeToString(ed25519.Sign(rootPriv, signingPub))
+			w.Write([]byte(`{"key":"` + key + `","signature":"` + sig + `"}`))
+		})
+		mux.HandleFunc("/targets/"+repoPath+".sig", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		v := &DistsignVerifier{RootKey: rootPub, SigningKeyURL: "signing-key.json", serverURL: srv.URL}
+		if err := v.Verify(repoPath, data.TargetFileMeta{}, localPath); err == nil {
+			t.Fatal("Verify succeeded despite a 500 fetching the artifact signature")
+		}
+	})
+}